@@ -0,0 +1,333 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package channel provides a client for invoking chaincode on a specific
+// channel, endorsing against peers selected from that channel's discovery
+// service and broadcasting the resulting transaction to its orderers.
+package channel
+
+import (
+	reqContext "context"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/golang/protobuf/proto"
+	msppb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+)
+
+// Request carries the chaincode invocation parameters common to both
+// Query and Execute.
+type Request struct {
+	ChaincodeID string
+	Fcn         string
+	Args        [][]byte
+}
+
+// Response is the result of a chaincode invocation.
+type Response struct {
+	TransactionID fab.TransactionID
+	Responses     []*fab.TransactionProposalResponse
+	Payload       []byte
+}
+
+// requestOptions holds the per-request settings gathered from a Request's
+// RequestOptions.
+type requestOptions struct {
+	targets []fab.Peer
+}
+
+// RequestOption configures a Query/Execute call.
+type RequestOption func(*requestOptions) error
+
+// WithTargets restricts the request to the given peers instead of the
+// full set the channel's discovery service returns.
+func WithTargets(targets ...fab.Peer) RequestOption {
+	return func(o *requestOptions) error {
+		o.targets = targets
+		return nil
+	}
+}
+
+// Client invokes chaincode on a single channel.
+type Client struct {
+	ctx       context.Channel
+	channelID string
+
+	// revocationFilter, when set via WithRevocationFallback, is
+	// consulted both to pick the initial candidate peers (Accept) and,
+	// on an endorsement failure classified as certificate-revocation, to
+	// eject the offending peer and replan the request against the
+	// remaining candidates (NotifyEndorsementError / selection.ReplanEndorsers).
+	// It is owned by the Client, not a Request, so that a peer it ejects
+	// stays ejected across separate Query/Execute calls instead of every
+	// call starting from a freshly constructed filter.
+	revocationFilter *selection.RevocationAwareFilter
+}
+
+// ClientOption configures a Client at construction time.
+type ClientOption func(*Client) error
+
+// New creates a channel Client for the channel channelProvider resolves,
+// applying opts.
+func New(channelProvider context.ChannelProvider, opts ...ClientOption) (*Client, error) {
+	ctx, err := channelProvider()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get channel context")
+	}
+	if ctx.ChannelID() == "" {
+		return nil, errors.New("channel ID is required")
+	}
+
+	c := &Client{ctx: ctx, channelID: ctx.ChannelID()}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, errors.WithMessage(err, "failed to apply client option")
+		}
+	}
+
+	return c, nil
+}
+
+// Query invokes req against endorsing peers without submitting the
+// resulting transaction to the orderer.
+func (c *Client) Query(req Request, options ...RequestOption) (Response, error) {
+	return c.invoke(req, false, options...)
+}
+
+// Execute invokes req, endorses it, and submits the resulting transaction
+// to the channel's orderers.
+func (c *Client) Execute(req Request, options ...RequestOption) (Response, error) {
+	return c.invoke(req, true, options...)
+}
+
+func (c *Client) prepareRequestOpts(options ...RequestOption) (requestOptions, error) {
+	opts := requestOptions{}
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return opts, errors.WithMessage(err, "failed to apply request option")
+		}
+	}
+	return opts, nil
+}
+
+// invoke endorses req against a set of candidate peers, grouped by MSP ID
+// so a revocation-triggered replan can pick one surviving peer per org,
+// then - for Execute - broadcasts the resulting transaction. The replan
+// goes ahead as long as at least one org still has a surviving peer; it
+// does not check the replanned set against the chaincode's endorsement
+// policy.
+func (c *Client) invoke(req Request, execute bool, options ...RequestOption) (Response, error) {
+	opts, err := c.prepareRequestOpts(options...)
+	if err != nil {
+		return Response{}, err
+	}
+
+	candidates, err := c.candidatesByOrg(opts)
+	if err != nil {
+		return Response{}, errors.WithMessage(err, "failed to determine candidate peers")
+	}
+
+	targets := c.selectTargets(candidates, opts)
+	if len(targets) == 0 {
+		return Response{}, errors.New("no targets available for request")
+	}
+
+	for {
+		responses, proposal, err := c.endorse(req, targets)
+		if err == nil {
+			return c.buildResponse(req, proposal, responses, execute)
+		}
+
+		rejected, ok := err.(*endorsementError)
+		if !ok || c.revocationFilter == nil || !c.revocationFilter.NotifyEndorsementError(rejected.peer, rejected.err) {
+			return Response{}, errors.WithMessage(err, "failed to endorse transaction proposal")
+		}
+
+		replanned, ok := selection.ReplanEndorsers(c.revocationFilter, candidates, 1)
+		if !ok {
+			return Response{}, errors.WithMessage(rejected.err, "not enough surviving endorsers after revocation")
+		}
+		targets = replanned
+	}
+}
+
+// endorsementError associates an endorsement failure with the specific
+// peer that produced it, so the caller can decide whether to eject just
+// that peer and retry rather than failing the whole request.
+type endorsementError struct {
+	peer fab.Peer
+	err  error
+}
+
+func (e *endorsementError) Error() string {
+	return e.err.Error()
+}
+
+// endorse sends txProposal to each target individually rather than as one
+// batched SendTransactionProposal call, so that any failure - a transport
+// error from the peer, or this SDK's own revocation check on the returned
+// endorsement - can always be attributed to the specific peer that
+// produced it, regardless of how many targets were in play. Batching them
+// would lose that attribution for anything but a single-target request,
+// which is exactly the multi-org (N-of-M) case the revocation fallback
+// needs to handle.
+func (c *Client) endorse(req Request, targets []fab.Peer) ([]*fab.TransactionProposalResponse, *fab.TransactionProposal, error) {
+	transactor, err := c.ctx.ChannelService().Transactor()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create channel transactor")
+	}
+
+	txProposal, err := transactor.CreateTransactionProposal(fab.ChaincodeInvokeRequest{
+		ChaincodeID: req.ChaincodeID,
+		Fcn:         req.Fcn,
+		Args:        req.Args,
+	})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create transaction proposal")
+	}
+
+	responses := make([]*fab.TransactionProposalResponse, 0, len(targets))
+	for _, target := range targets {
+		peerResponses, err := transactor.SendTransactionProposal(txProposal, []fab.ProposalProcessor{target})
+		if err != nil {
+			return nil, nil, &endorsementError{peer: target, err: err}
+		}
+
+		for _, response := range peerResponses {
+			if err := c.checkEndorserRevocation(response); err != nil {
+				return nil, nil, &endorsementError{peer: target, err: err}
+			}
+		}
+
+		responses = append(responses, peerResponses...)
+	}
+
+	return responses, txProposal, nil
+}
+
+// checkEndorserRevocation consults the active cauthdsl.RevocationChecker
+// against the certificate embedded in response's endorsement, so a peer
+// whose certificate was revoked more recently than its own last channel
+// validation (e.g. picked up by this SDK instance's CRL/OCSP refresh
+// machinery before the peer itself noticed) is still rejected, instead of
+// relying solely on the peer's own validation of the endorsing identity.
+func (c *Client) checkEndorserRevocation(response *fab.TransactionProposalResponse) error {
+	cert, issuer, err := endorserCertificate(response)
+	if err != nil {
+		// The endorsement's identity couldn't be parsed as an x.509
+		// certificate (e.g. an idemix identity) - leave the decision to
+		// the peer's own validation rather than failing the request over
+		// an identity shape this check doesn't understand.
+		return nil
+	}
+
+	revoked, err := cauthdsl.CheckRevocation(cert, issuer)
+	if err != nil {
+		return errors.WithMessage(err, "revocation check failed for endorser")
+	}
+	if revoked {
+		return errors.New("the certificate has been revoked")
+	}
+	return nil
+}
+
+// endorserCertificate extracts the x.509 certificate (and a stand-in
+// issuer populated with just the issuing CA's subject, which is all
+// Checker.IsRevoked needs to look up its CRL store) from the serialized
+// identity response's endorsement was signed by.
+func endorserCertificate(response *fab.TransactionProposalResponse) (cert, issuer *x509.Certificate, err error) {
+	endorsement := response.ProposalResponse.GetEndorsement()
+	if endorsement == nil {
+		return nil, nil, errors.New("endorsement response has no endorsement")
+	}
+
+	identity := &msppb.SerializedIdentity{}
+	if err := proto.Unmarshal(endorsement.Endorser, identity); err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to unmarshal endorser identity")
+	}
+
+	block, _ := pem.Decode(identity.IdBytes)
+	if block == nil {
+		return nil, nil, errors.New("endorser identity does not contain a PEM certificate")
+	}
+
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to parse endorser certificate")
+	}
+
+	return cert, &x509.Certificate{Subject: cert.Issuer}, nil
+}
+
+func (c *Client) buildResponse(req Request, proposal *fab.TransactionProposal, responses []*fab.TransactionProposalResponse, execute bool) (Response, error) {
+	resp := Response{
+		TransactionID: proposal.TxnID,
+		Responses:     responses,
+	}
+	if len(responses) > 0 {
+		resp.Payload = responses[0].ProposalResponse.GetResponse().GetPayload()
+	}
+
+	if !execute {
+		return resp, nil
+	}
+
+	transactor, err := c.ctx.ChannelService().Transactor()
+	if err != nil {
+		return Response{}, errors.WithMessage(err, "failed to create channel transactor")
+	}
+
+	if err := transactor.SendTransaction(reqContext.Background(), proposal, responses); err != nil {
+		return Response{}, errors.WithMessage(err, "failed to send transaction to orderer")
+	}
+
+	return resp, nil
+}
+
+// candidatesByOrg groups the channel's discovery-reported peers by MSP
+// ID, mirroring the shape selection.ReplanEndorsers expects.
+func (c *Client) candidatesByOrg(opts requestOptions) (map[string][]fab.Peer, error) {
+	if len(opts.targets) > 0 {
+		candidates := make(map[string][]fab.Peer)
+		for _, peer := range opts.targets {
+			candidates[peer.MSPID()] = append(candidates[peer.MSPID()], peer)
+		}
+		return candidates, nil
+	}
+
+	peers, err := c.ctx.ChannelService().Discovery().GetPeers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get discovered peers")
+	}
+
+	candidates := make(map[string][]fab.Peer)
+	for _, peer := range peers {
+		candidates[peer.MSPID()] = append(candidates[peer.MSPID()], peer)
+	}
+	return candidates, nil
+}
+
+// selectTargets returns every candidate when no revocation filter is
+// configured. Otherwise it picks one peer per org that the filter's Accept
+// admits, so an already-ejected peer is never chosen as the initial target.
+func (c *Client) selectTargets(candidates map[string][]fab.Peer, opts requestOptions) []fab.Peer {
+	if c.revocationFilter == nil {
+		var targets []fab.Peer
+		for _, peers := range candidates {
+			targets = append(targets, peers...)
+		}
+		return targets
+	}
+
+	targets, _ := selection.ReplanEndorsers(c.revocationFilter, candidates, 0)
+	return targets
+}
@@ -0,0 +1,29 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channel
+
+import (
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/common/selection"
+)
+
+// WithRevocationFallback enables revocation-aware endorser selection on
+// a Client: when an endorsement fails with a certificate-revocation
+// error, the offending peer is ejected from selection (via a
+// selection.RevocationAwareFilter) for opts.Cooldown, and the request is
+// replanned against one surviving endorser per org, as long as at least
+// one org still has one, instead of simply returning the error to the
+// caller. The replanned set is not checked against the chaincode's
+// endorsement policy. The filter is built once, at Client construction, and
+// reused across every subsequent Query/Execute, so opts.Cooldown is
+// actually honored - a RequestOption would instead rebuild it, and the
+// ejection, from scratch on every call.
+func WithRevocationFallback(opts selection.RevocationAwareFilterOpts) ClientOption {
+	return func(c *Client) error {
+		c.revocationFilter = selection.NewRevocationAwareFilter(opts)
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package selection
+
+import "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+
+// ReplanEndorsers re-derives a set of endorsing peers that still
+// satisfies an N-of-M chaincode policy (e.g. one built with
+// third_party/.../cauthdsl.SignedByAnyMember or
+// SignedByNOutOfGivenRole) after a RevocationAwareFilter has ejected one
+// or more peers. candidates is grouped by MSP ID, mirroring how a
+// channel's endorsing peers are organized by org; nOutOf is the minimum
+// number of distinct orgs that must contribute a surviving peer (1 for
+// SignedByAnyMember, len(mspIDs) for SignedByAllMembers, etc).
+//
+// It returns one surviving peer per org that still has one, plus false
+// if fewer than nOutOf orgs have a surviving peer.
+func ReplanEndorsers(filter *RevocationAwareFilter, candidates map[string][]fab.Peer, nOutOf int) ([]fab.Peer, bool) {
+	var plan []fab.Peer
+
+	for _, peers := range candidates {
+		for _, peer := range peers {
+			if filter.Accept(peer) {
+				plan = append(plan, peer)
+				break
+			}
+		}
+	}
+
+	return plan, len(plan) >= nOutOf
+}
@@ -0,0 +1,126 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package selection
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+)
+
+var logger = logging.NewLogger("fabsdk/selection")
+
+// DefaultRevocationCooldown is how long a peer is ejected from endorser
+// selection after an endorsement attempt against it fails with a
+// certificate-revocation error, when RevocationAwareFilterOpts does not
+// override it.
+const DefaultRevocationCooldown = 5 * time.Minute
+
+// RevocationAwareFilterOpts configures a RevocationAwareFilter.
+type RevocationAwareFilterOpts struct {
+	// Cooldown is how long an ejected peer is excluded from selection
+	// before it is given another chance.
+	Cooldown time.Duration
+}
+
+// RevocationAwareFilter is a fab.TargetFilter that ejects peers for a
+// cooldown period once an endorsement against them has been classified
+// as a certificate-revocation failure (status.Group == status.EndorserClientStatus
+// with a "certificate has been revoked" message, surfaced by
+// pkg/common/errors/status, or raised proactively by
+// channel.Client.checkEndorserRevocation against the certificate a
+// successful endorsement was actually signed with). channel.Client
+// consults it when built with channel.WithRevocationFallback, replanning
+// against the surviving endorsers via ReplanEndorsers; the static and the
+// Fabric discovery-based selection services do not use it.
+type RevocationAwareFilter struct {
+	cooldown time.Duration
+
+	mu      sync.Mutex
+	ejected map[string]time.Time
+}
+
+// NewRevocationAwareFilter creates a RevocationAwareFilter with the
+// given options.
+func NewRevocationAwareFilter(opts RevocationAwareFilterOpts) *RevocationAwareFilter {
+	cooldown := opts.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultRevocationCooldown
+	}
+	return &RevocationAwareFilter{
+		cooldown: cooldown,
+		ejected:  make(map[string]time.Time),
+	}
+}
+
+// Accept implements fab.TargetFilter. It rejects any peer that is
+// currently within its revocation cooldown window (see
+// NotifyEndorsementError), and otherwise accepts every candidate - actual
+// revocation checking happens after endorsement, against the certificate
+// the endorsement was really signed with, not before selection against
+// whatever identity a fab.Peer happens to expose.
+func (f *RevocationAwareFilter) Accept(peer fab.Peer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	until, ok := f.ejected[peer.URL()]
+	if !ok {
+		return true
+	}
+	if time.Now().After(until) {
+		delete(f.ejected, peer.URL())
+		return true
+	}
+	return false
+}
+
+// NotifyEndorsementError inspects err from an endorsement attempt
+// against peer and, if it classifies as a certificate-revocation
+// failure, ejects peer from selection for the configured cooldown. It
+// returns true if the peer was ejected.
+func (f *RevocationAwareFilter) NotifyEndorsementError(peer fab.Peer, err error) bool {
+	if !isRevocationError(err) {
+		return false
+	}
+
+	f.mu.Lock()
+	f.ejected[peer.URL()] = time.Now().Add(f.cooldown)
+	f.mu.Unlock()
+
+	logger.Warnf("ejecting peer %s from endorser selection for %s: %s", peer.URL(), f.cooldown, err)
+	return true
+}
+
+// isRevocationError reports whether err, as classified by
+// pkg/common/errors/status, represents a peer rejecting an identity
+// because its certificate has been revoked (as opposed to some other
+// endorsement failure that a retry against the same peer might recover
+// from).
+func isRevocationError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	s, ok := status.FromError(err)
+	if ok && (s.Group == status.EndorserClientStatus || s.Group == status.EndorserServerStatus) && containsRevocationMessage(s.Message) {
+		return true
+	}
+
+	// Not every transport surfaces a classifiable status.Status - e.g. a
+	// raw gRPC error returned before the SDK wraps it. Fall back to
+	// scanning the error text itself so those are still caught.
+	return containsRevocationMessage(err.Error())
+}
+
+func containsRevocationMessage(msg string) bool {
+	const marker = "certificate has been revoked"
+	return strings.Contains(msg, marker)
+}
@@ -0,0 +1,384 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+)
+
+// LifecycleInstallCCRequest contains the parameters for installing a
+// chaincode package on a set of peers via the Fabric v2.x `_lifecycle`
+// system chaincode, as driven by the `peer lifecycle chaincode install`
+// CLI command.
+type LifecycleInstallCCRequest struct {
+	Label   string
+	Package []byte
+}
+
+// LifecycleInstallCCResponse contains the result of a `_lifecycle`
+// chaincode install invocation against a single peer.
+type LifecycleInstallCCResponse struct {
+	Target    string
+	PackageID string
+	Status    int32
+}
+
+// LifecycleInstalledCC describes a chaincode package that has been
+// installed on a peer, as returned by QueryInstalledCC.
+type LifecycleInstalledCC struct {
+	PackageID  string
+	Label      string
+	References map[string][]LifecycleCCReference
+}
+
+// LifecycleCCReference identifies a chaincode definition that an
+// installed package has been approved/committed against on a channel.
+type LifecycleCCReference struct {
+	Name    string
+	Version string
+}
+
+// LifecycleApproveCCRequest contains the parameters required to approve
+// a chaincode definition for an org, equivalent to
+// `peer lifecycle chaincode approveformyorg`.
+type LifecycleApproveCCRequest struct {
+	Name              string
+	Version           string
+	PackageID         string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+}
+
+// LifecycleApproveCCResponse contains the result of approving a
+// chaincode definition against a single peer/orderer pair.
+type LifecycleApproveCCResponse struct {
+	TransactionID fab.TransactionID
+}
+
+// LifecycleCheckCCCommitReadinessRequest mirrors the parameters of
+// `peer lifecycle chaincode checkcommitreadiness`.
+type LifecycleCheckCCCommitReadinessRequest struct {
+	Name              string
+	Version           string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+}
+
+// LifecycleCommitReadiness reports, per org MSP ID, whether that org has
+// already approved the chaincode definition being committed.
+type LifecycleCommitReadiness struct {
+	Approvals map[string]bool
+}
+
+// LifecycleCommitCCRequest mirrors the parameters of
+// `peer lifecycle chaincode commit`.
+type LifecycleCommitCCRequest struct {
+	Name              string
+	Version           string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+}
+
+// LifecycleCommitCCResponse contains the result of committing a
+// chaincode definition on a channel.
+type LifecycleCommitCCResponse struct {
+	TransactionID fab.TransactionID
+}
+
+// LifecycleCommittedCC describes a chaincode definition that has been
+// committed on a channel, as returned by QueryCommittedCC.
+type LifecycleCommittedCC struct {
+	Name              string
+	Version           string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+	Approvals         map[string]bool
+}
+
+// LifecycleInstallCC installs a chaincode package on the target peers
+// using the `_lifecycle` system chaincode. Unlike the v1.x InstallCC,
+// the package is not associated with a channel until it is later
+// approved and committed.
+func (rc *Client) LifecycleInstallCC(req LifecycleInstallCCRequest, options ...RequestOption) ([]LifecycleInstallCCResponse, error) {
+	if req.Label == "" {
+		return nil, errors.New("label is required")
+	}
+	if len(req.Package) == 0 {
+		return nil, errors.New("package is required")
+	}
+
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to determine target peers")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets available for install")
+	}
+
+	responses := make([]LifecycleInstallCCResponse, 0, len(targets))
+	for _, target := range targets {
+		packageID, status, err := resource.LifecycleInstallChaincode(rc.ctx, req.Package, target)
+		if err != nil {
+			return nil, errors.WithMessage(err, "lifecycle install failed for peer "+target.URL())
+		}
+		responses = append(responses, LifecycleInstallCCResponse{
+			Target:    target.URL(),
+			PackageID: packageID,
+			Status:    status,
+		})
+	}
+
+	return responses, nil
+}
+
+// QueryInstalledCC returns the chaincode packages installed on the
+// target peer via the `_lifecycle` system chaincode.
+func (rc *Client) QueryInstalledCC(options ...RequestOption) ([]LifecycleInstalledCC, error) {
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to determine target peers")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets available for query")
+	}
+
+	installed, err := resource.LifecycleQueryInstalledChaincodes(rc.ctx, targets[0])
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to query installed chaincodes")
+	}
+
+	return toLifecycleInstalledCCs(installed), nil
+}
+
+// LifecycleApproveCC submits an approval transaction for the given
+// chaincode definition on behalf of the client's org.
+func (rc *Client) LifecycleApproveCC(channelID string, req LifecycleApproveCCRequest, options ...RequestOption) (LifecycleApproveCCResponse, error) {
+	if channelID == "" {
+		return LifecycleApproveCCResponse{}, errors.New("channel ID is required")
+	}
+
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return LifecycleApproveCCResponse{}, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return LifecycleApproveCCResponse{}, errors.WithMessage(err, "failed to determine target peers")
+	}
+
+	txnID, err := resource.LifecycleApproveChaincode(rc.ctx, channelID, resource.LifecycleApproveChaincodeRequest{
+		Name:              req.Name,
+		Version:           req.Version,
+		PackageID:         req.PackageID,
+		Sequence:          req.Sequence,
+		EndorsementPlugin: req.EndorsementPlugin,
+		ValidationPlugin:  req.ValidationPlugin,
+		SignaturePolicy:   req.SignaturePolicy,
+		CollectionConfig:  req.CollectionConfig,
+		InitRequired:      req.InitRequired,
+	}, targets)
+	if err != nil {
+		return LifecycleApproveCCResponse{}, errors.WithMessage(err, "failed to approve chaincode definition")
+	}
+
+	return LifecycleApproveCCResponse{TransactionID: txnID}, nil
+}
+
+// LifecycleQueryApprovedCC returns the chaincode definition this org has
+// approved for the given name/sequence on a channel.
+func (rc *Client) LifecycleQueryApprovedCC(channelID string, name string, sequence int64, options ...RequestOption) (LifecycleCommittedCC, error) {
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return LifecycleCommittedCC{}, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return LifecycleCommittedCC{}, errors.WithMessage(err, "failed to determine target peers")
+	}
+	if len(targets) == 0 {
+		return LifecycleCommittedCC{}, errors.New("no targets available for query")
+	}
+
+	approved, err := resource.LifecycleQueryApprovedChaincode(rc.ctx, channelID, name, sequence, targets[0])
+	if err != nil {
+		return LifecycleCommittedCC{}, errors.WithMessage(err, "failed to query approved chaincode definition")
+	}
+
+	return toLifecycleCommittedCC(approved), nil
+}
+
+// LifecycleCheckCCCommitReadiness reports which orgs have approved the
+// given chaincode definition, prior to it being committed.
+func (rc *Client) LifecycleCheckCCCommitReadiness(channelID string, req LifecycleCheckCCCommitReadinessRequest, options ...RequestOption) (LifecycleCommitReadiness, error) {
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return LifecycleCommitReadiness{}, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return LifecycleCommitReadiness{}, errors.WithMessage(err, "failed to determine target peers")
+	}
+	if len(targets) == 0 {
+		return LifecycleCommitReadiness{}, errors.New("no targets available for query")
+	}
+
+	approvals, err := resource.LifecycleCheckCommitReadiness(rc.ctx, channelID, resource.LifecycleApproveChaincodeRequest{
+		Name:              req.Name,
+		Version:           req.Version,
+		Sequence:          req.Sequence,
+		EndorsementPlugin: req.EndorsementPlugin,
+		ValidationPlugin:  req.ValidationPlugin,
+		SignaturePolicy:   req.SignaturePolicy,
+		CollectionConfig:  req.CollectionConfig,
+		InitRequired:      req.InitRequired,
+	}, targets[0])
+	if err != nil {
+		return LifecycleCommitReadiness{}, errors.WithMessage(err, "failed to check commit readiness")
+	}
+
+	return LifecycleCommitReadiness{Approvals: approvals}, nil
+}
+
+// LifecycleCommitCC commits the chaincode definition on the channel once
+// a sufficient number of orgs have approved it.
+func (rc *Client) LifecycleCommitCC(channelID string, req LifecycleCommitCCRequest, options ...RequestOption) (LifecycleCommitCCResponse, error) {
+	if channelID == "" {
+		return LifecycleCommitCCResponse{}, errors.New("channel ID is required")
+	}
+
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return LifecycleCommitCCResponse{}, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return LifecycleCommitCCResponse{}, errors.WithMessage(err, "failed to determine target peers")
+	}
+
+	txnID, err := resource.LifecycleCommitChaincode(rc.ctx, channelID, resource.LifecycleApproveChaincodeRequest{
+		Name:              req.Name,
+		Version:           req.Version,
+		Sequence:          req.Sequence,
+		EndorsementPlugin: req.EndorsementPlugin,
+		ValidationPlugin:  req.ValidationPlugin,
+		SignaturePolicy:   req.SignaturePolicy,
+		CollectionConfig:  req.CollectionConfig,
+		InitRequired:      req.InitRequired,
+	}, targets)
+	if err != nil {
+		return LifecycleCommitCCResponse{}, errors.WithMessage(err, "failed to commit chaincode definition")
+	}
+
+	return LifecycleCommitCCResponse{TransactionID: txnID}, nil
+}
+
+// QueryCommittedCC returns the chaincode definitions committed on the
+// given channel, equivalent to
+// `peer lifecycle chaincode querycommitted`.
+func (rc *Client) QueryCommittedCC(channelID string, options ...RequestOption) ([]LifecycleCommittedCC, error) {
+	if channelID == "" {
+		return nil, errors.New("channel ID is required")
+	}
+
+	opts, err := rc.prepareRequestOpts(options...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to prepare request options")
+	}
+
+	targets, err := rc.calculateTargets(opts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to determine target peers")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets available for query")
+	}
+
+	committed, err := resource.LifecycleQueryCommittedChaincodes(rc.ctx, channelID, targets[0])
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to query committed chaincode definitions")
+	}
+
+	result := make([]LifecycleCommittedCC, len(committed))
+	for i, cc := range committed {
+		result[i] = toLifecycleCommittedCC(cc)
+	}
+
+	return result, nil
+}
+
+// toLifecycleInstalledCCs converts the pkg/fab/resource-owned installed
+// chaincode descriptions into the resmgmt-level type exposed by this
+// client, keeping resmgmt's public API free of a direct dependency on
+// resource's representation.
+func toLifecycleInstalledCCs(installed []resource.LifecycleInstalledCC) []LifecycleInstalledCC {
+	result := make([]LifecycleInstalledCC, len(installed))
+	for i, cc := range installed {
+		refs := make(map[string][]LifecycleCCReference, len(cc.References))
+		for channelID, ccRefs := range cc.References {
+			converted := make([]LifecycleCCReference, len(ccRefs))
+			for j, ref := range ccRefs {
+				converted[j] = LifecycleCCReference{Name: ref.Name, Version: ref.Version}
+			}
+			refs[channelID] = converted
+		}
+		result[i] = LifecycleInstalledCC{
+			PackageID:  cc.PackageID,
+			Label:      cc.Label,
+			References: refs,
+		}
+	}
+	return result
+}
+
+// toLifecycleCommittedCC converts a pkg/fab/resource-owned committed
+// chaincode definition into the resmgmt-level type.
+func toLifecycleCommittedCC(cc resource.LifecycleCommittedCC) LifecycleCommittedCC {
+	return LifecycleCommittedCC{
+		Name:              cc.Name,
+		Version:           cc.Version,
+		Sequence:          cc.Sequence,
+		EndorsementPlugin: cc.EndorsementPlugin,
+		ValidationPlugin:  cc.ValidationPlugin,
+		SignaturePolicy:   cc.SignaturePolicy,
+		CollectionConfig:  cc.CollectionConfig,
+		InitRequired:      cc.InitRequired,
+		Approvals:         cc.Approvals,
+	}
+}
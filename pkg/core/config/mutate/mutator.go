@@ -0,0 +1,225 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package mutate provides a fluent API for deriving a runtime
+// NetworkConfig from a base config, without callers having to open-code
+// lookup.New(backend).UnmarshalKey / map mutation / MockConfigBackend
+// composition themselves. It is aimed at the kind of ad-hoc, in-test
+// config rewriting (swapping a peer, adding an entity matcher, ...) that
+// integration tests and multi-org topologies repeatedly need.
+package mutate
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/lookup"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/mocks"
+)
+
+// Mutator accumulates changes to a fab.NetworkConfig loaded from a base
+// core.ConfigProvider, to be layered back on top of that base via
+// AsConfigProvider.
+type Mutator struct {
+	deferred []func(core.ConfigBackend) error
+
+	peers          map[string]fab.PeerConfig
+	organizations  map[string]fab.OrganizationConfig
+	channels       map[string]fab.ChannelEndpointConfig
+	entityMatchers map[string][]fab.MatchConfig
+
+	touchedPeers          bool
+	touchedOrganizations  bool
+	touchedChannels       bool
+	touchedEntityMatchers bool
+}
+
+// New creates an empty Mutator. Mutation methods only unmarshal the
+// relevant section (peers, organizations, channels, entityMatchers) out
+// of the base config the first time they are called, once
+// AsConfigProvider resolves that base.
+func New() *Mutator {
+	return &Mutator{}
+}
+
+func (m *Mutator) loadPeers(backend core.ConfigBackend) error {
+	if m.touchedPeers {
+		return nil
+	}
+	m.touchedPeers = true
+	m.peers = make(map[string]fab.PeerConfig)
+	return lookup.New(backend).UnmarshalKey("peers", &m.peers)
+}
+
+func (m *Mutator) loadOrganizations(backend core.ConfigBackend) error {
+	if m.touchedOrganizations {
+		return nil
+	}
+	m.touchedOrganizations = true
+	m.organizations = make(map[string]fab.OrganizationConfig)
+	return lookup.New(backend).UnmarshalKey("organizations", &m.organizations)
+}
+
+func (m *Mutator) loadChannels(backend core.ConfigBackend) error {
+	if m.touchedChannels {
+		return nil
+	}
+	m.touchedChannels = true
+	m.channels = make(map[string]fab.ChannelEndpointConfig)
+	return lookup.New(backend).UnmarshalKey("channels", &m.channels)
+}
+
+func (m *Mutator) loadEntityMatchers(backend core.ConfigBackend) error {
+	if m.touchedEntityMatchers {
+		return nil
+	}
+	m.touchedEntityMatchers = true
+	m.entityMatchers = make(map[string][]fab.MatchConfig)
+	return lookup.New(backend).UnmarshalKey("entityMatchers", &m.entityMatchers)
+}
+
+// AddPeer adds (or overwrites) the named peer entry.
+func (m *Mutator) AddPeer(name string, cfg fab.PeerConfig) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadPeers(backend); err != nil {
+			return err
+		}
+		m.peers[name] = cfg
+		return nil
+	})
+}
+
+// ReplacePeer removes oldName and adds newName with cfg, the common case
+// of swapping one peer's endpoint for another (e.g. peer0.org2 ->
+// peer1.org2 for a revoked-peer scenario).
+func (m *Mutator) ReplacePeer(oldName, newName string, cfg fab.PeerConfig) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadPeers(backend); err != nil {
+			return err
+		}
+		delete(m.peers, oldName)
+		m.peers[newName] = cfg
+		return nil
+	})
+}
+
+// RemovePeer removes the named peer entry.
+func (m *Mutator) RemovePeer(name string) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadPeers(backend); err != nil {
+			return err
+		}
+		delete(m.peers, name)
+		return nil
+	})
+}
+
+// AddOrgPeer sets an org's peer list and MSP ID, the common pairing of
+// pointing an org at a replacement peer (see ReplacePeer) while keeping
+// it consistent with the org's own config entry.
+func (m *Mutator) AddOrgPeer(org string, mspID string, peerNames []string) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadOrganizations(backend); err != nil {
+			return err
+		}
+		orgCfg := m.organizations[org]
+		orgCfg.MSPID = mspID
+		orgCfg.Peers = peerNames
+		m.organizations[org] = orgCfg
+		return nil
+	})
+}
+
+// SetOrgMSPID sets just the MSP ID of an existing org entry.
+func (m *Mutator) SetOrgMSPID(org string, mspID string) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadOrganizations(backend); err != nil {
+			return err
+		}
+		orgCfg := m.organizations[org]
+		orgCfg.MSPID = mspID
+		m.organizations[org] = orgCfg
+		return nil
+	})
+}
+
+// SetChannelPeerRoles removes oldPeer (if present) and sets newPeer's
+// roles on the given channel, e.g. swapping which peer is the
+// endorsing/chaincode-query/ledger-query/event source for a channel
+// after ReplacePeer.
+func (m *Mutator) SetChannelPeerRoles(channelID string, oldPeer string, newPeer string, roles fab.PeerChannelConfig) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadChannels(backend); err != nil {
+			return err
+		}
+		ch := m.channels[channelID]
+		if ch.Peers == nil {
+			ch.Peers = make(map[string]fab.PeerChannelConfig)
+		}
+		if oldPeer != "" {
+			delete(ch.Peers, oldPeer)
+		}
+		ch.Peers[newPeer] = roles
+		m.channels[channelID] = ch
+		return nil
+	})
+}
+
+// PrependEntityMatcher inserts match into the front of the named
+// entity-matcher list (e.g. "peer"), so it is tried before any matchers
+// already present in the base config.
+func (m *Mutator) PrependEntityMatcher(kind string, match fab.MatchConfig) *Mutator {
+	return m.enqueue(func(backend core.ConfigBackend) error {
+		if err := m.loadEntityMatchers(backend); err != nil {
+			return err
+		}
+		m.entityMatchers[kind] = append([]fab.MatchConfig{match}, m.entityMatchers[kind]...)
+		return nil
+	})
+}
+
+// enqueue defers fn until AsConfigProvider resolves the base backend.
+func (m *Mutator) enqueue(fn func(core.ConfigBackend) error) *Mutator {
+	m.deferred = append(m.deferred, fn)
+	return m
+}
+
+// AsConfigProvider resolves base and applies every mutation recorded on
+// m against it, returning a core.ConfigProvider backed by a
+// mocks.MockConfigBackend that overlays the mutated sections ("peers",
+// "organizations", "channels", "entityMatchers") on top of base for
+// everything else.
+func (m *Mutator) AsConfigProvider(base core.ConfigProvider) core.ConfigProvider {
+	return func() (core.ConfigBackend, error) {
+		backend, err := base()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load base config backend")
+		}
+
+		for _, fn := range m.deferred {
+			if err := fn(backend); err != nil {
+				return nil, errors.WithMessage(err, "failed to apply config mutation")
+			}
+		}
+
+		backendMap := make(map[string]interface{})
+		if m.touchedPeers {
+			backendMap["peers"] = m.peers
+		}
+		if m.touchedOrganizations {
+			backendMap["organizations"] = m.organizations
+		}
+		if m.touchedChannels {
+			backendMap["channels"] = m.channels
+		}
+		if m.touchedEntityMatchers {
+			backendMap["entityMatchers"] = m.entityMatchers
+		}
+
+		return &mocks.MockConfigBackend{KeyValueMap: backendMap, CustomBackend: backend}, nil
+	}
+}
@@ -0,0 +1,456 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resource
+
+import (
+	reqContext "context"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+)
+
+// lifecycleCCName is the name of Fabric's built-in v2.x lifecycle system
+// chaincode that install/approve/commit/query operations are invoked
+// against, in place of the v1.x `lscc`.
+const lifecycleCCName = "_lifecycle"
+
+// LifecycleApproveChaincodeRequest carries the chaincode definition
+// parameters shared by LifecycleApproveChaincode, LifecycleCommitChaincode
+// and LifecycleCheckCommitReadiness - the same fields the `_lifecycle`
+// chaincode requires to identify a definition on all three invocations.
+type LifecycleApproveChaincodeRequest struct {
+	Name              string
+	Version           string
+	PackageID         string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+}
+
+// LifecycleInstalledCC describes a chaincode package installed on a
+// peer, as reported by `_lifecycle`'s QueryInstalledChaincodes.
+type LifecycleInstalledCC struct {
+	PackageID  string
+	Label      string
+	References map[string][]LifecycleCCReference
+}
+
+// LifecycleCCReference identifies a chaincode definition that an
+// installed package has been approved/committed against on a channel.
+type LifecycleCCReference struct {
+	Name    string
+	Version string
+}
+
+// LifecycleCommittedCC describes a chaincode definition committed (or, for
+// LifecycleQueryApprovedChaincode, approved) on a channel.
+type LifecycleCommittedCC struct {
+	Name              string
+	Version           string
+	Sequence          int64
+	EndorsementPlugin string
+	ValidationPlugin  string
+	SignaturePolicy   *fab.SignaturePolicyEnvelope
+	CollectionConfig  []*fab.CollectionConfig
+	InitRequired      bool
+	Approvals         map[string]bool
+}
+
+// LifecycleInstallChaincode builds and sends an `_lifecycle`
+// InstallChaincode transaction proposal to target, returning the
+// package ID the peer assigned to the installed package (a hash of the
+// package content, as computed by the peer itself) and the chaincode
+// response status.
+func LifecycleInstallChaincode(ctx context.Client, pkg []byte, target fab.ProposalProcessor) (string, int32, error) {
+	argsBytes, err := proto.Marshal(&lb.InstallChaincodeArgs{ChaincodeInstallPackage: pkg})
+	if err != nil {
+		return "", 0, errors.WithMessage(err, "failed to marshal InstallChaincodeArgs")
+	}
+
+	responses, err := sendLifecycleProposal(ctx, "", "InstallChaincode", argsBytes, []fab.ProposalProcessor{target})
+	if err != nil {
+		return "", 0, err
+	}
+
+	payload, status, err := lifecycleResponsePayload(responses[0])
+	if err != nil {
+		return "", status, err
+	}
+
+	result := &lb.InstallChaincodeResult{}
+	if err := proto.Unmarshal(payload, result); err != nil {
+		return "", status, errors.WithMessage(err, "failed to unmarshal InstallChaincodeResult")
+	}
+
+	return result.PackageId, status, nil
+}
+
+// LifecycleQueryInstalledChaincodes returns the chaincode packages
+// installed on target.
+func LifecycleQueryInstalledChaincodes(ctx context.Client, target fab.ProposalProcessor) ([]LifecycleInstalledCC, error) {
+	argsBytes, err := proto.Marshal(&lb.QueryInstalledChaincodesArgs{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal QueryInstalledChaincodesArgs")
+	}
+
+	responses, err := sendLifecycleProposal(ctx, "", "QueryInstalledChaincodes", argsBytes, []fab.ProposalProcessor{target})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _, err := lifecycleResponsePayload(responses[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &lb.QueryInstalledChaincodesResult{}
+	if err := proto.Unmarshal(payload, result); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal QueryInstalledChaincodesResult")
+	}
+
+	installed := make([]LifecycleInstalledCC, 0, len(result.InstalledChaincodes))
+	for _, cc := range result.InstalledChaincodes {
+		refs := make(map[string][]LifecycleCCReference, len(cc.References))
+		for channelID, chaincodes := range cc.References {
+			ccRefs := make([]LifecycleCCReference, 0, len(chaincodes.Chaincodes))
+			for _, ref := range chaincodes.Chaincodes {
+				ccRefs = append(ccRefs, LifecycleCCReference{Name: ref.Name, Version: ref.Version})
+			}
+			refs[channelID] = ccRefs
+		}
+		installed = append(installed, LifecycleInstalledCC{
+			PackageID:  cc.PackageId,
+			Label:      cc.Label,
+			References: refs,
+		})
+	}
+
+	return installed, nil
+}
+
+// LifecycleApproveChaincode submits an ApproveChaincodeDefinitionForMyOrg
+// transaction on channelID, endorsed by targets and broadcast to the
+// channel's orderer.
+func LifecycleApproveChaincode(ctx context.Client, channelID string, req LifecycleApproveChaincodeRequest, targets []fab.ProposalProcessor) (fab.TransactionID, error) {
+	argsBytes, err := proto.Marshal(approveArgs(req))
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to marshal ApproveChaincodeDefinitionForMyOrgArgs")
+	}
+
+	return sendLifecycleTransaction(ctx, channelID, "ApproveChaincodeDefinitionForMyOrg", argsBytes, targets)
+}
+
+// LifecycleQueryApprovedChaincode returns the chaincode definition this
+// org has approved for name/sequence on channelID.
+func LifecycleQueryApprovedChaincode(ctx context.Client, channelID string, name string, sequence int64, target fab.ProposalProcessor) (LifecycleCommittedCC, error) {
+	argsBytes, err := proto.Marshal(&lb.QueryApprovedChaincodeDefinitionArgs{Name: name, Sequence: sequence})
+	if err != nil {
+		return LifecycleCommittedCC{}, errors.WithMessage(err, "failed to marshal QueryApprovedChaincodeDefinitionArgs")
+	}
+
+	responses, err := sendLifecycleProposal(ctx, channelID, "QueryApprovedChaincodeDefinition", argsBytes, []fab.ProposalProcessor{target})
+	if err != nil {
+		return LifecycleCommittedCC{}, err
+	}
+
+	payload, _, err := lifecycleResponsePayload(responses[0])
+	if err != nil {
+		return LifecycleCommittedCC{}, err
+	}
+
+	result := &lb.QueryApprovedChaincodeDefinitionResult{}
+	if err := proto.Unmarshal(payload, result); err != nil {
+		return LifecycleCommittedCC{}, errors.WithMessage(err, "failed to unmarshal QueryApprovedChaincodeDefinitionResult")
+	}
+
+	return LifecycleCommittedCC{
+		Name:     name,
+		Version:  result.Version,
+		Sequence: result.Sequence,
+	}, nil
+}
+
+// LifecycleCheckCommitReadiness reports, per org MSP ID, whether that
+// org has already approved the chaincode definition described by req.
+func LifecycleCheckCommitReadiness(ctx context.Client, channelID string, req LifecycleApproveChaincodeRequest, target fab.ProposalProcessor) (map[string]bool, error) {
+	argsBytes, err := proto.Marshal(checkCommitReadinessArgs(req))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal CheckCommitReadinessArgs")
+	}
+
+	responses, err := sendLifecycleProposal(ctx, channelID, "CheckCommitReadiness", argsBytes, []fab.ProposalProcessor{target})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _, err := lifecycleResponsePayload(responses[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &lb.CheckCommitReadinessResult{}
+	if err := proto.Unmarshal(payload, result); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal CheckCommitReadinessResult")
+	}
+
+	return result.Approvals, nil
+}
+
+// LifecycleCommitChaincode submits a CommitChaincodeDefinition
+// transaction on channelID, endorsed by targets and broadcast to the
+// channel's orderer.
+func LifecycleCommitChaincode(ctx context.Client, channelID string, req LifecycleApproveChaincodeRequest, targets []fab.ProposalProcessor) (fab.TransactionID, error) {
+	argsBytes, err := proto.Marshal(commitArgs(req))
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to marshal CommitChaincodeDefinitionArgs")
+	}
+
+	return sendLifecycleTransaction(ctx, channelID, "CommitChaincodeDefinition", argsBytes, targets)
+}
+
+// LifecycleQueryCommittedChaincodes returns the chaincode definitions
+// committed on channelID.
+func LifecycleQueryCommittedChaincodes(ctx context.Client, channelID string, target fab.ProposalProcessor) ([]LifecycleCommittedCC, error) {
+	argsBytes, err := proto.Marshal(&lb.QueryChaincodeDefinitionsArgs{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to marshal QueryChaincodeDefinitionsArgs")
+	}
+
+	responses, err := sendLifecycleProposal(ctx, channelID, "QueryChaincodeDefinitions", argsBytes, []fab.ProposalProcessor{target})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _, err := lifecycleResponsePayload(responses[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &lb.QueryChaincodeDefinitionsResult{}
+	if err := proto.Unmarshal(payload, result); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal QueryChaincodeDefinitionsResult")
+	}
+
+	committed := make([]LifecycleCommittedCC, 0, len(result.ChaincodeDefinitions))
+	for _, def := range result.ChaincodeDefinitions {
+		committed = append(committed, LifecycleCommittedCC{
+			Name:     def.Name,
+			Version:  def.Version,
+			Sequence: def.Sequence,
+		})
+	}
+
+	return committed, nil
+}
+
+func approveArgs(req LifecycleApproveChaincodeRequest) *lb.ApproveChaincodeDefinitionForMyOrgArgs {
+	return &lb.ApproveChaincodeDefinitionForMyOrgArgs{
+		Name:                req.Name,
+		Version:             req.Version,
+		Sequence:            req.Sequence,
+		EndorsementPlugin:   req.EndorsementPlugin,
+		ValidationPlugin:    req.ValidationPlugin,
+		ValidationParameter: signaturePolicyBytes(req.SignaturePolicy),
+		InitRequired:        req.InitRequired,
+		Collections:         collectionConfigPackage(req.CollectionConfig),
+		Source: &lb.ChaincodeSource{
+			Type: &lb.ChaincodeSource_LocalPackage{
+				LocalPackage: &lb.ChaincodeSource_Local{PackageId: req.PackageID},
+			},
+		},
+	}
+}
+
+func checkCommitReadinessArgs(req LifecycleApproveChaincodeRequest) *lb.CheckCommitReadinessArgs {
+	return &lb.CheckCommitReadinessArgs{
+		Name:                req.Name,
+		Version:             req.Version,
+		Sequence:            req.Sequence,
+		EndorsementPlugin:   req.EndorsementPlugin,
+		ValidationPlugin:    req.ValidationPlugin,
+		ValidationParameter: signaturePolicyBytes(req.SignaturePolicy),
+		InitRequired:        req.InitRequired,
+		Collections:         collectionConfigPackage(req.CollectionConfig),
+	}
+}
+
+func commitArgs(req LifecycleApproveChaincodeRequest) *lb.CommitChaincodeDefinitionArgs {
+	return &lb.CommitChaincodeDefinitionArgs{
+		Name:                req.Name,
+		Version:             req.Version,
+		Sequence:            req.Sequence,
+		EndorsementPlugin:   req.EndorsementPlugin,
+		ValidationPlugin:    req.ValidationPlugin,
+		ValidationParameter: signaturePolicyBytes(req.SignaturePolicy),
+		InitRequired:        req.InitRequired,
+		Collections:         collectionConfigPackage(req.CollectionConfig),
+	}
+}
+
+func signaturePolicyBytes(policy *fab.SignaturePolicyEnvelope) []byte {
+	if policy == nil {
+		return nil
+	}
+	b, err := proto.Marshal(policy)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// collectionConfigPackage converts the SDK-level private-data collection
+// configs a caller attached to a LifecycleApproveChaincodeRequest into the
+// proto shape `_lifecycle` expects, so Approve/CheckCommitReadiness/Commit
+// actually submit them instead of silently endorsing the definition
+// without its collections.
+func collectionConfigPackage(configs []*fab.CollectionConfig) *pb.CollectionConfigPackage {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	collections := make([]*pb.CollectionConfig, len(configs))
+	for i, cfg := range configs {
+		collections[i] = &pb.CollectionConfig{
+			Payload: &pb.CollectionConfig_StaticCollectionConfig{
+				StaticCollectionConfig: &pb.StaticCollectionConfig{
+					Name:              cfg.Name,
+					RequiredPeerCount: cfg.RequiredPeerCount,
+					MaximumPeerCount:  cfg.MaximumPeerCount,
+					BlockToLive:       cfg.BlockToLive,
+					MemberOrgsPolicy:  collectionPolicyConfig(cfg.MemberOrgsPolicy),
+				},
+			},
+		}
+	}
+
+	return &pb.CollectionConfigPackage{Config: collections}
+}
+
+func collectionPolicyConfig(policy *fab.SignaturePolicyEnvelope) *pb.CollectionPolicyConfig {
+	if policy == nil {
+		return nil
+	}
+	return &pb.CollectionPolicyConfig{
+		Payload: &pb.CollectionPolicyConfig_SignaturePolicy{
+			SignaturePolicy: policy,
+		},
+	}
+}
+
+// sendLifecycleProposal builds and endorses an `_lifecycle` transaction
+// proposal without submitting it to the orderer, for the operations that
+// are not ordered (InstallChaincode and the Query*/Check* operations).
+func sendLifecycleProposal(ctx context.Client, channelID string, fcn string, argsBytes []byte, targets []fab.ProposalProcessor) ([]*fab.TransactionProposalResponse, error) {
+	txh, err := txn.NewHeader(ctx, channelID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create transaction header")
+	}
+
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID: lifecycleCCName,
+		Fcn:         fcn,
+		Args:        [][]byte{argsBytes},
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create lifecycle proposal")
+	}
+
+	responses, err := txn.SendProposal(reqContext.Background(), proposal, targets)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to send lifecycle proposal")
+	}
+	if len(responses) == 0 {
+		return nil, errors.New("no response received from lifecycle proposal")
+	}
+
+	return responses, nil
+}
+
+// sendLifecycleTransaction endorses an `_lifecycle` proposal against
+// targets and broadcasts the resulting transaction to channelID's
+// orderer, for the write operations (Approve*/Commit*).
+func sendLifecycleTransaction(ctx context.Client, channelID string, fcn string, argsBytes []byte, targets []fab.ProposalProcessor) (fab.TransactionID, error) {
+	txh, err := txn.NewHeader(ctx, channelID)
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to create transaction header")
+	}
+
+	proposal, err := txn.CreateChaincodeInvokeProposal(txh, fab.ChaincodeInvokeRequest{
+		ChaincodeID: lifecycleCCName,
+		Fcn:         fcn,
+		Args:        [][]byte{argsBytes},
+	})
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to create lifecycle proposal")
+	}
+
+	responses, err := txn.SendProposal(reqContext.Background(), proposal, targets)
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to send lifecycle proposal")
+	}
+	for _, response := range responses {
+		if _, _, err := lifecycleResponsePayload(response); err != nil {
+			return fab.TransactionID(""), err
+		}
+	}
+
+	tx, err := txn.New(fab.TransactionRequest{Proposal: proposal, ProposalResponses: responses})
+	if err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to assemble lifecycle transaction")
+	}
+
+	orderers, err := channelOrderers(ctx, channelID)
+	if err != nil {
+		return fab.TransactionID(""), err
+	}
+
+	if _, err := txn.Send(reqContext.Background(), tx, orderers); err != nil {
+		return fab.TransactionID(""), errors.WithMessage(err, "failed to send lifecycle transaction to orderer")
+	}
+
+	return proposal.TxnID, nil
+}
+
+// channelOrderers resolves the orderer endpoints configured for
+// channelID into the fab.Orderer instances needed to broadcast a
+// lifecycle transaction.
+func channelOrderers(ctx context.Client, channelID string) ([]fab.Orderer, error) {
+	ordererConfigs, err := ctx.EndpointConfig().ChannelOrderers(channelID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to look up channel orderers")
+	}
+
+	orderers := make([]fab.Orderer, 0, len(ordererConfigs))
+	for _, cfg := range ordererConfigs {
+		orderer, err := ctx.InfraProvider().CreateOrdererFromConfig(&cfg)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create orderer from config")
+		}
+		orderers = append(orderers, orderer)
+	}
+
+	return orderers, nil
+}
+
+// lifecycleResponsePayload validates a single endorsement response's
+// chaincode status (200, mirroring shim.OK) and returns its payload.
+func lifecycleResponsePayload(response *fab.TransactionProposalResponse) ([]byte, int32, error) {
+	ccResponse := response.ProposalResponse.GetResponse()
+	if ccResponse.GetStatus() != 200 {
+		return nil, ccResponse.GetStatus(), errors.Errorf("bad lifecycle response %d: %s", ccResponse.GetStatus(), ccResponse.GetMessage())
+	}
+	return ccResponse.GetPayload(), ccResponse.GetStatus(), nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package fabsdk provides the main entry point to the Fabric SDK, bundling
+// a resolved config into the contexts (org/user/channel) that the
+// resmgmt/channel clients are constructed against.
+package fabsdk
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	contextAPI "github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	mspimpl "github.com/hyperledger/fabric-sdk-go/pkg/msp"
+)
+
+// options collects the settings gathered from New's Options.
+type options struct {
+	crlRefreshInterval time.Duration
+}
+
+// Option configures a FabricSDK at construction time.
+type Option func(*options) error
+
+// contextOptions collects the org/user an identity a Context or
+// ChannelContext resolves to is scoped to.
+type contextOptions struct {
+	orgID    string
+	userName string
+}
+
+// ContextOption configures the org/user a Context or ChannelContext
+// resolves to.
+type ContextOption func(*contextOptions) error
+
+// WithOrg scopes a Context/ChannelContext to the named org.
+func WithOrg(org string) ContextOption {
+	return func(o *contextOptions) error {
+		o.orgID = org
+		return nil
+	}
+}
+
+// WithUser scopes a Context/ChannelContext to the named user within its
+// org.
+func WithUser(user string) ContextOption {
+	return func(o *contextOptions) error {
+		o.userName = user
+		return nil
+	}
+}
+
+// FabricSDK bundles a resolved config together with the background
+// services (such as the CRLRefresher) that run for the lifetime of the
+// SDK instance rather than a single request, and is the single
+// construction path Context/ChannelContext resolve org/user-scoped
+// contexts against.
+type FabricSDK struct {
+	configProvider core.ConfigProvider
+	endpointConfig fab.EndpointConfig
+	infraProvider  fab.InfraProvider
+
+	crlRefresher *mspimpl.CRLRefresher
+}
+
+// New creates a FabricSDK from configProvider, applying opts.
+func New(configProvider core.ConfigProvider, opts ...Option) (*FabricSDK, error) {
+	o := options{}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, errors.WithMessage(err, "failed to apply option")
+		}
+	}
+
+	backend, err := configProvider()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load config backend")
+	}
+
+	endpointConfig, err := fab.ConfigFromBackend(backend)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load endpoint config")
+	}
+
+	infraProvider, err := fab.NewInfraProvider(endpointConfig)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create infra provider")
+	}
+
+	sdk := &FabricSDK{
+		configProvider: configProvider,
+		endpointConfig: endpointConfig,
+		infraProvider:  infraProvider,
+	}
+
+	// The CRLRefresher is always constructed so that CRLRefresher() gives
+	// callers (including tests) a real, SDK-owned instance to register
+	// MSPCRLStores against - WithCRLRefreshInterval only controls whether
+	// its background ticker is started; RefreshNow can still be driven
+	// manually against a refresher that was never Start()-ed.
+	sdk.crlRefresher = mspimpl.NewCRLRefresher(crlProviderFromConfig(backend), o.crlRefreshInterval)
+	if o.crlRefreshInterval > 0 {
+		sdk.crlRefresher.Start()
+	}
+
+	return sdk, nil
+}
+
+// CRLRefresher returns the SDK's CRLRefresher, so that, for example, a
+// Checker built from the same SDK's config can RegisterCRLStore an
+// MSPCRLStore that this refresher then keeps current.
+func (sdk *FabricSDK) CRLRefresher() *mspimpl.CRLRefresher {
+	return sdk.crlRefresher
+}
+
+// Close releases the background services owned by the SDK instance.
+func (sdk *FabricSDK) Close() {
+	sdk.crlRefresher.Stop()
+}
+
+// Context returns a ClientProvider that resolves to an org/user-scoped
+// context.Client, for use by resmgmt.New and other org-admin-level
+// clients.
+func (sdk *FabricSDK) Context(options ...ContextOption) contextAPI.ClientProvider {
+	return func() (contextAPI.Client, error) {
+		opts, err := resolveContextOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		return sdk.clientContext(opts), nil
+	}
+}
+
+// ChannelContext returns a ChannelProvider that resolves to an
+// org/user-scoped context.Channel for channelID, for use by channel.New.
+func (sdk *FabricSDK) ChannelContext(channelID string, options ...ContextOption) contextAPI.ChannelProvider {
+	return func() (contextAPI.Channel, error) {
+		opts, err := resolveContextOptions(options)
+		if err != nil {
+			return nil, err
+		}
+
+		client := sdk.clientContext(opts)
+		channelService, err := sdk.infraProvider.CreateChannelService(client, channelID)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create channel service")
+		}
+
+		return &channelContext{clientContext: client, channelID: channelID, channelService: channelService}, nil
+	}
+}
+
+func resolveContextOptions(options []ContextOption) (contextOptions, error) {
+	opts := contextOptions{}
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return opts, errors.WithMessage(err, "failed to apply context option")
+		}
+	}
+	if opts.orgID == "" {
+		return opts, errors.New("org is required")
+	}
+	return opts, nil
+}
+
+func (sdk *FabricSDK) clientContext(opts contextOptions) *clientContext {
+	return &clientContext{
+		sdk:      sdk,
+		orgID:    opts.orgID,
+		userName: opts.userName,
+	}
+}
+
+// clientContext implements contextAPI.Client for a single org/user pair.
+type clientContext struct {
+	sdk      *FabricSDK
+	orgID    string
+	userName string
+}
+
+func (c *clientContext) EndpointConfig() fab.EndpointConfig {
+	return c.sdk.endpointConfig
+}
+
+func (c *clientContext) InfraProvider() fab.InfraProvider {
+	return c.sdk.infraProvider
+}
+
+// channelContext implements contextAPI.Channel on top of a clientContext.
+type channelContext struct {
+	*clientContext
+	channelID      string
+	channelService fab.ChannelService
+}
+
+func (c *channelContext) ChannelID() string {
+	return c.channelID
+}
+
+func (c *channelContext) ChannelService() fab.ChannelService {
+	return c.channelService
+}
+
+func crlProviderFromConfig(backend core.ConfigBackend) mspimpl.CRLProvider {
+	return mspimpl.NewFileCRLProvider(nil)
+}
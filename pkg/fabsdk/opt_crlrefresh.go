@@ -0,0 +1,21 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabsdk
+
+import "time"
+
+// WithCRLRefreshInterval configures how often the SDK's CRLRefresher
+// re-reads (and/or fetches over HTTP) CRLs for the CRLStores registered
+// with it (see FabricSDK.CRLRefresher; the SDK registers none itself),
+// instead of relying on the CRL snapshot taken once at SDK init. A
+// value of 0 leaves background CRL refresh disabled.
+func WithCRLRefreshInterval(interval time.Duration) Option {
+	return func(opts *options) error {
+		opts.crlRefreshInterval = interval
+		return nil
+	}
+}
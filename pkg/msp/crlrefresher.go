@@ -0,0 +1,287 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+)
+
+var crlLogger = logging.NewLogger("fabsdk/msp")
+
+// DefaultCRLRefreshInterval is the interval NewCRLRefresher uses when it
+// is given a non-positive one. fabsdk starts the refresh loop only when
+// fabsdk.WithCRLRefreshInterval supplies a positive interval, so this
+// default applies only to a refresher started directly via Start.
+const DefaultCRLRefreshInterval = time.Minute
+
+// CRLProvider supplies the parsed CRLs for an issuing CA. The provider
+// returned by NewFileCRLProvider re-reads the CRL files it was given and
+// follows the issuer's CRLDistributionPoints; the one fabsdk builds is
+// given no files, so it only fetches distribution points. Callers may
+// supply their own, e.g. one that polls a Fabric-CA `/api/v1/crl`
+// endpoint instead of (or in addition to) local files.
+type CRLProvider interface {
+	// FetchCRLs returns the current CRLs for issuer.
+	FetchCRLs(issuer *x509.Certificate) ([]*pkix.CertificateList, error)
+}
+
+// CRLRefreshEvent is emitted on a CRLRefresher's event channel whenever
+// a background refresh swaps in a new CRL for an MSP.
+type CRLRefreshEvent struct {
+	MSPID string
+	// Err is non-nil when the refresh attempt failed; the previously
+	// loaded CRL (if any) remains active in that case.
+	Err error
+}
+
+// fileCRLProvider is the default CRLProvider: it re-reads the CRL files
+// at the paths it was given, and additionally fetches any
+// CRLDistributionPoints advertised by the issuer cert.
+type fileCRLProvider struct {
+	paths  []string
+	client *http.Client
+}
+
+// NewFileCRLProvider returns a CRLProvider that re-reads the given CRL
+// file paths on every refresh.
+func NewFileCRLProvider(paths []string) CRLProvider {
+	return &fileCRLProvider{paths: paths, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *fileCRLProvider) FetchCRLs(issuer *x509.Certificate) ([]*pkix.CertificateList, error) {
+	var crls []*pkix.CertificateList
+
+	for _, path := range p.paths {
+		raw, err := ioutil.ReadFile(path) // nolint: gosec
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read CRL file "+path)
+		}
+		crl, err := x509.ParseCRL(raw)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to parse CRL file "+path)
+		}
+		crls = append(crls, crl)
+	}
+
+	for _, url := range issuer.CRLDistributionPoints {
+		resp, err := p.client.Get(url) // nolint: gosec
+		if err != nil {
+			crlLogger.Warnf("failed to fetch CRL from distribution point %s: %s", url, err)
+			continue
+		}
+		raw, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close() // nolint: errcheck
+		if err != nil {
+			crlLogger.Warnf("failed to read CRL from distribution point %s: %s", url, err)
+			continue
+		}
+		crl, err := x509.ParseCRL(raw)
+		if err != nil {
+			crlLogger.Warnf("failed to parse CRL from distribution point %s: %s", url, err)
+			continue
+		}
+		crls = append(crls, crl)
+	}
+
+	return crls, nil
+}
+
+// CRLStore is the subset of an in-memory MSP that CRLRefresher swaps
+// CRLs into once a refresh has verified them against the issuing CA.
+type CRLStore interface {
+	// Issuer returns the CA certificate that signs this MSP's CRLs.
+	Issuer() *x509.Certificate
+	// SetCRLs atomically replaces the CRLs this MSP uses to validate
+	// identities going forward.
+	SetCRLs(crls []*pkix.CertificateList)
+}
+
+// MSPCRLStore is a concrete CRLStore backed by an in-memory MSP's issuer
+// certificate and current CRL set. Registering one with a CRLRefresher
+// (CRLRefresher.Register) keeps its CRLs fresh; registering the same
+// store with a Checker (Checker.RegisterCRLStore) makes the refreshed
+// CRLs the actual source IsRevoked consults for that issuer, closing the
+// loop between background CRL refresh and policy evaluation.
+type MSPCRLStore struct {
+	mspID  string
+	issuer *x509.Certificate
+
+	mu   sync.RWMutex
+	crls []*pkix.CertificateList
+}
+
+// NewMSPCRLStore creates an MSPCRLStore for the given MSP ID and issuer.
+func NewMSPCRLStore(mspID string, issuer *x509.Certificate) *MSPCRLStore {
+	return &MSPCRLStore{mspID: mspID, issuer: issuer}
+}
+
+// Issuer implements CRLStore.
+func (s *MSPCRLStore) Issuer() *x509.Certificate {
+	return s.issuer
+}
+
+// SetCRLs implements CRLStore.
+func (s *MSPCRLStore) SetCRLs(crls []*pkix.CertificateList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.crls = crls
+}
+
+// IsRevoked reports whether serial appears on any CRL currently held by
+// the store.
+func (s *MSPCRLStore) IsRevoked(serial *big.Int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, crl := range s.crls {
+		for _, rc := range crl.TBSCertList.RevokedCertificates {
+			if rc.SerialNumber.Cmp(serial) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CRLRefresher periodically re-reads (and/or fetches over HTTP) the
+// CRLs for a set of registered MSPs and atomically swaps them into the
+// in-memory MSP, instead of the CRL snapshot taken once at SDK init.
+type CRLRefresher struct {
+	provider CRLProvider
+	interval time.Duration
+
+	mu      sync.Mutex
+	stores  map[string]CRLStore
+	events  chan CRLRefreshEvent
+	stopCh  chan struct{}
+	stopped bool
+}
+
+// NewCRLRefresher creates a CRLRefresher that refreshes every interval
+// using the given CRLProvider. Use fabsdk.WithCRLRefreshInterval to
+// configure the interval at SDK construction time.
+func NewCRLRefresher(provider CRLProvider, interval time.Duration) *CRLRefresher {
+	if interval <= 0 {
+		interval = DefaultCRLRefreshInterval
+	}
+	return &CRLRefresher{
+		provider: provider,
+		interval: interval,
+		stores:   make(map[string]CRLStore),
+		events:   make(chan CRLRefreshEvent, 16),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// SetProvider replaces the CRLProvider used by subsequent refreshes. It
+// exists mainly for tests that need to point an already-constructed
+// CRLRefresher (e.g. one owned by a FabricSDK instance) at CRL files that
+// don't exist until after the SDK/refresher were created.
+func (r *CRLRefresher) SetProvider(provider CRLProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.provider = provider
+}
+
+// Register adds an MSP's CRLStore to the set refreshed on each tick.
+func (r *CRLRefresher) Register(mspID string, store CRLStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stores[mspID] = store
+}
+
+// Events returns the channel on which CRLRefreshEvents are emitted.
+// Context providers can subscribe to it to react to revocations (e.g.
+// to eject a peer from the selection service) without restarting the
+// SDK.
+func (r *CRLRefresher) Events() <-chan CRLRefreshEvent {
+	return r.events
+}
+
+// Start begins the periodic refresh loop in a background goroutine. It
+// returns immediately; call Stop to terminate the loop.
+func (r *CRLRefresher) Start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.refreshAll()
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the refresh loop. It is safe to call Stop more than
+// once.
+func (r *CRLRefresher) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	close(r.stopCh)
+}
+
+// RefreshNow triggers a single refresh pass outside of the normal
+// ticker cadence, primarily for tests that cannot wait out a full
+// interval.
+func (r *CRLRefresher) RefreshNow() {
+	r.refreshAll()
+}
+
+func (r *CRLRefresher) refreshAll() {
+	r.mu.Lock()
+	provider := r.provider
+	stores := make(map[string]CRLStore, len(r.stores))
+	for id, s := range r.stores {
+		stores[id] = s
+	}
+	r.mu.Unlock()
+
+	for mspID, store := range stores {
+		crls, err := provider.FetchCRLs(store.Issuer())
+		if err != nil {
+			r.emit(CRLRefreshEvent{MSPID: mspID, Err: err})
+			continue
+		}
+
+		verified := make([]*pkix.CertificateList, 0, len(crls))
+		for _, crl := range crls {
+			if err := store.Issuer().CheckCRLSignature(crl); err != nil {
+				crlLogger.Warnf("dropping CRL for MSP %s with invalid signature: %s", mspID, err)
+				continue
+			}
+			verified = append(verified, crl)
+		}
+
+		store.SetCRLs(verified)
+		r.emit(CRLRefreshEvent{MSPID: mspID})
+	}
+}
+
+func (r *CRLRefresher) emit(event CRLRefreshEvent) {
+	select {
+	case r.events <- event:
+	default:
+		crlLogger.Warnf("CRLRefresher event channel full, dropping event for MSP %s", event.MSPID)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/logging"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config/lookup"
+)
+
+var ocspLogger = logging.NewLogger("fabsdk/msp")
+
+// RevocationMode selects which revocation source(s) the SDK consults
+// when validating an identity, as configured under the `revocation:`
+// section of an MSP's client config.
+type RevocationMode string
+
+const (
+	// RevocationModeCRL checks only CRLs baked into the MSP config (the
+	// pre-existing, default behavior).
+	RevocationModeCRL RevocationMode = "crl"
+	// RevocationModeOCSP checks only OCSP, issuing a request to the
+	// AuthorityInformationAccess URL in the certificate whenever no cached
+	// response for it is still within CacheTTL.
+	RevocationModeOCSP RevocationMode = "ocsp"
+	// RevocationModeBoth consults both CRL and OCSP; the identity is
+	// rejected if either source reports it as revoked.
+	RevocationModeBoth RevocationMode = "both"
+)
+
+// RevocationConfig is the `client.revocation` section of the SDK config
+// (see RevocationConfigFromBackend).
+type RevocationConfig struct {
+	// Mode selects crl, ocsp or both. Defaults to RevocationModeCRL.
+	Mode RevocationMode
+	// SoftFail determines what happens when the OCSP responder cannot be
+	// reached: if true, the identity is treated as valid (logged as a
+	// warning); if false (hardFail), validation fails closed.
+	SoftFail bool
+	// CacheTTL is how long a good/revoked OCSP response is cached before
+	// it is re-checked against the responder.
+	CacheTTL time.Duration
+	// ResponderOverrides maps an issuing CA's subject common name to an
+	// explicit OCSP responder URL, overriding the certificate's
+	// AuthorityInformationAccess extension.
+	ResponderOverrides map[string]string
+}
+
+// DefaultCacheTTL is used when a RevocationConfig does not specify one.
+const DefaultCacheTTL = 5 * time.Minute
+
+// revocationConfigYAML mirrors RevocationConfig, but with CacheTTL as a
+// duration string (e.g. "30s"), matching how the rest of the SDK's client
+// config expresses durations under `client:`.
+type revocationConfigYAML struct {
+	Mode               string            `mapstructure:"mode"`
+	SoftFail           bool              `mapstructure:"softFail"`
+	CacheTTL           string            `mapstructure:"cacheTTL"`
+	ResponderOverrides map[string]string `mapstructure:"responderOverrides"`
+}
+
+// RevocationConfigFromBackend unmarshals the `client.revocation` section
+// of backend into a RevocationConfig, so a RevocationChecker can be built
+// from config-file settings instead of requiring callers to construct a
+// RevocationConfig by hand. A missing `client.revocation` section
+// unmarshals to the zero value, which NewRevocationChecker already
+// defaults to RevocationModeCRL.
+func RevocationConfigFromBackend(backend core.ConfigBackend) (RevocationConfig, error) {
+	var raw revocationConfigYAML
+	if err := lookup.New(backend).UnmarshalKey("client.revocation", &raw); err != nil {
+		return RevocationConfig{}, errors.WithMessage(err, "failed to unmarshal client.revocation config")
+	}
+
+	config := RevocationConfig{
+		Mode:               RevocationMode(raw.Mode),
+		SoftFail:           raw.SoftFail,
+		ResponderOverrides: raw.ResponderOverrides,
+	}
+
+	if raw.CacheTTL != "" {
+		ttl, err := time.ParseDuration(raw.CacheTTL)
+		if err != nil {
+			return RevocationConfig{}, errors.WithMessage(err, "failed to parse client.revocation.cacheTTL")
+		}
+		config.CacheTTL = ttl
+	}
+
+	return config, nil
+}
+
+type ocspCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// OCSPClient issues OCSP requests to validate whether a certificate has
+// been revoked, caching responses for the configured TTL.
+type OCSPClient struct {
+	config RevocationConfig
+	client *http.Client
+
+	mu    sync.RWMutex
+	cache map[string]ocspCacheEntry
+}
+
+// NewOCSPClient creates an OCSPClient for the given revocation config.
+func NewOCSPClient(config RevocationConfig) *OCSPClient {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	return &OCSPClient{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  make(map[string]ocspCacheEntry),
+	}
+}
+
+// IsRevoked reports whether cert (issued by issuer) is revoked,
+// consulting a cached OCSP response if still fresh, otherwise issuing a
+// fresh OCSP request against the responder URL found in the
+// AuthorityInformationAccess extension (or a configured override).
+func (c *OCSPClient) IsRevoked(cert, issuer *x509.Certificate) (bool, error) {
+	key := cacheKey(cert)
+
+	c.mu.RLock()
+	entry, ok := c.cache[key]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.queryResponder(cert, issuer)
+	if err != nil {
+		if c.config.SoftFail {
+			ocspLogger.Warnf("OCSP responder unreachable for serial %s, soft-failing as not revoked: %s", cert.SerialNumber, err)
+			return false, nil
+		}
+		return false, errors.WithMessage(err, "OCSP check failed and softFail is disabled")
+	}
+
+	c.mu.Lock()
+	c.cache[key] = ocspCacheEntry{revoked: revoked, expiresAt: time.Now().Add(c.config.CacheTTL)}
+	c.mu.Unlock()
+
+	return revoked, nil
+}
+
+func (c *OCSPClient) queryResponder(cert, issuer *x509.Certificate) (bool, error) {
+	responderURL := c.responderURL(cert, issuer)
+	if responderURL == "" {
+		return false, errors.New("no OCSP responder URL available for certificate")
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to create OCSP request")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, responderURL, bytes.NewReader(req))
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to build OCSP HTTP request")
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return false, errors.WithMessage(err, "OCSP request failed")
+	}
+	defer httpResp.Body.Close() // nolint: errcheck
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to read OCSP response")
+	}
+
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed to parse OCSP response")
+	}
+
+	return resp.Status == ocsp.Revoked, nil
+}
+
+func (c *OCSPClient) responderURL(cert, issuer *x509.Certificate) string {
+	if override, ok := c.config.ResponderOverrides[issuer.Subject.CommonName]; ok {
+		return override
+	}
+	if len(cert.OCSPServer) > 0 {
+		return cert.OCSPServer[0]
+	}
+	return ""
+}
+
+func cacheKey(cert *x509.Certificate) string {
+	return cert.Issuer.String() + ":" + cert.SerialNumber.String()
+}
@@ -0,0 +1,89 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"crypto/x509"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/core"
+	"github.com/hyperledger/fabric-sdk-go/third_party/github.com/hyperledger/fabric/common/cauthdsl"
+)
+
+// Checker implements cauthdsl.RevocationChecker on top of CRLs kept fresh
+// by a CRLRefresher (see RegisterCRLStore) plus, depending on Mode, an
+// OCSPClient. NewRevocationChecker installs it as the vendored cauthdsl
+// package's process-wide checker, which cauthdsl.CheckRevocation consults;
+// cauthdsl's policy evaluators themselves do not call it.
+type Checker struct {
+	mode RevocationMode
+	ocsp *OCSPClient
+
+	mu        sync.RWMutex
+	crlStores map[string]*MSPCRLStore
+}
+
+// NewRevocationChecker builds the Checker driven by the `revocation:`
+// section of an MSP's client config and registers it as the active
+// checker that cauthdsl.CheckRevocation consults.
+func NewRevocationChecker(config RevocationConfig) *Checker {
+	if config.Mode == "" {
+		config.Mode = RevocationModeCRL
+	}
+
+	checker := &Checker{mode: config.Mode, crlStores: make(map[string]*MSPCRLStore)}
+	if config.Mode == RevocationModeOCSP || config.Mode == RevocationModeBoth {
+		checker.ocsp = NewOCSPClient(config)
+	}
+
+	cauthdsl.SetRevocationChecker(checker)
+	return checker
+}
+
+// NewRevocationCheckerFromBackend is the config-file-driven counterpart
+// to NewRevocationChecker: it reads the `client.revocation` section of
+// backend (see RevocationConfigFromBackend) instead of requiring callers
+// to build a RevocationConfig literal themselves.
+func NewRevocationCheckerFromBackend(backend core.ConfigBackend) (*Checker, error) {
+	config, err := RevocationConfigFromBackend(backend)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load revocation config")
+	}
+	return NewRevocationChecker(config), nil
+}
+
+// RegisterCRLStore associates an MSPCRLStore (typically also registered
+// with a CRLRefresher, so its CRLs stay current) with this checker's
+// CRL-mode revocation check, keyed by the issuer's subject. Once
+// registered, IsRevoked consults the store's live CRLs for that issuer
+// instead of treating CRL revocation as out of scope.
+func (c *Checker) RegisterCRLStore(store *MSPCRLStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.crlStores[store.Issuer().Subject.String()] = store
+}
+
+// IsRevoked reports whether cert has been revoked under the configured
+// mode: by an issuer's CRL, as refreshed into a registered MSPCRLStore
+// (RevocationModeCRL/Both), and/or by OCSP (RevocationModeOCSP/Both).
+func (c *Checker) IsRevoked(cert, issuer *x509.Certificate) (bool, error) {
+	if c.mode == RevocationModeCRL || c.mode == RevocationModeBoth {
+		c.mu.RLock()
+		store, ok := c.crlStores[issuer.Subject.String()]
+		c.mu.RUnlock()
+		if ok && store.IsRevoked(cert.SerialNumber) {
+			return true, nil
+		}
+	}
+
+	if c.ocsp == nil {
+		return false, nil
+	}
+	return c.ocsp.IsRevoked(cert, issuer)
+}
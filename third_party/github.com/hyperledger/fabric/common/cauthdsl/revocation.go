@@ -0,0 +1,69 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cauthdsl
+
+import (
+	"crypto/x509"
+	"sync"
+)
+
+// RevocationChecker is the revocation source that CheckRevocation
+// consults. It allows the caller to plug in a revocation source - such as
+// the msp package's CRL+OCSP based checker - without this vendored
+// cauthdsl copy depending on pkg/msp directly. This package's policy
+// evaluators (e.g. for SignedByAnyMember/SignedByNOutOfGivenRole) do not
+// call it; it is reached only through CheckRevocation.
+type RevocationChecker interface {
+	// IsRevoked reports whether cert (issued by issuer) has been
+	// revoked. A non-nil error means the check itself failed; callers
+	// should treat that the same as a hard-fail revocation unless they
+	// have configured soft-fail behavior upstream.
+	IsRevoked(cert, issuer *x509.Certificate) (bool, error)
+}
+
+// noopRevocationChecker is a no-op used when no checker has been
+// registered: it defers entirely to CRL-based checks already performed
+// by the identity's own Validate().
+type noopRevocationChecker struct{}
+
+func (noopRevocationChecker) IsRevoked(cert, issuer *x509.Certificate) (bool, error) {
+	return false, nil
+}
+
+var (
+	revocationCheckerMu sync.RWMutex
+	revocationChecker   RevocationChecker = noopRevocationChecker{}
+)
+
+// SetRevocationChecker installs the RevocationChecker consulted by
+// CheckRevocation. Passing nil restores the no-op default (CRL-only,
+// performed elsewhere in the MSP).
+//
+// The checker is process-global, matching how this vendored cauthdsl copy
+// is otherwise consumed (policy evaluation here has no notion of "which
+// SDK instance" it is running under). There is no per-instance
+// alternative: with more than one SDK instance in the same process, the
+// checker installed last applies to all of them.
+func SetRevocationChecker(checker RevocationChecker) {
+	if checker == nil {
+		checker = noopRevocationChecker{}
+	}
+	revocationCheckerMu.Lock()
+	revocationChecker = checker
+	revocationCheckerMu.Unlock()
+}
+
+// CheckRevocation consults the currently-installed RevocationChecker (see
+// SetRevocationChecker) to determine whether cert, issued by issuer, has
+// been revoked. It is the exported entry point callers outside this
+// package use to reach the installed checker.
+func CheckRevocation(cert, issuer *x509.Certificate) (bool, error) {
+	revocationCheckerMu.RLock()
+	checker := revocationChecker
+	revocationCheckerMu.RUnlock()
+	return checker.IsRevoked(cert, issuer)
+}